@@ -0,0 +1,115 @@
+package jsondb
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/bytedance/sonic"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes and decodes the values a DB stores. The same interface
+// serves two distinct roles, selected by OptCodec and OptFileCodec
+// respectively: encoding one record's value, and encoding an entire on-disk
+// file (a DB's map of records, or a directory-backed Collection's).
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// FileExtension is the conventional suffix for files written with this
+	// codec, e.g. ".json" or ".cbor".
+	FileExtension() string
+}
+
+// JSONCodec encodes with the standard library encoding/json package.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (JSONCodec) FileExtension() string              { return ".json" }
+
+// SonicCodec encodes JSON using bytedance/sonic for higher throughput. It is
+// the default Codec used by Open.
+type SonicCodec struct{}
+
+func (SonicCodec) Marshal(v any) ([]byte, error)      { return sonic.Marshal(v) }
+func (SonicCodec) Unmarshal(data []byte, v any) error { return sonic.Unmarshal(data, v) }
+func (SonicCodec) FileExtension() string              { return ".json" }
+
+// CBORCodec encodes records as CBOR (RFC 8949).
+type CBORCodec struct{}
+
+func (CBORCodec) Marshal(v any) ([]byte, error)      { return cbor.Marshal(v) }
+func (CBORCodec) Unmarshal(data []byte, v any) error { return cbor.Unmarshal(data, v) }
+func (CBORCodec) FileExtension() string              { return ".cbor" }
+
+// MsgpackCodec encodes records as MessagePack.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (MsgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+func (MsgpackCodec) FileExtension() string              { return ".msgpack" }
+
+// jsonShapedCodec reports whether codec's encoding is JSON text, as opposed
+// to an arbitrary byte format (CBOR, MessagePack, ciphertext, ...). Some
+// features embed a codec's raw output inside a larger JSON document (WAL
+// records) or decode into `any` expecting encoding/json's representation of
+// objects as map[string]any (path traversal); only JSONCodec and SonicCodec
+// satisfy either assumption.
+func jsonShapedCodec(codec Codec) bool {
+	switch codec.(type) {
+	case JSONCodec, SonicCodec:
+		return true
+	default:
+		return false
+	}
+}
+
+// EncryptedCodec wraps another Codec, AEAD-encrypting its marshaled output.
+// Each call to Marshal generates a fresh random nonce, which is prepended to
+// the ciphertext so Unmarshal can recover it. It is meant to be set via
+// OptFileCodec so it encrypts the whole on-disk file as one unit; using it
+// as OptCodec would AEAD-seal each record individually, and the resulting
+// ciphertext isn't valid JSON, so it can't be embedded back into a
+// JSONCodec/SonicCodec file envelope.
+type EncryptedCodec struct {
+	Codec Codec
+	AEAD  cipher.AEAD
+}
+
+func (e EncryptedCodec) Marshal(v any) ([]byte, error) {
+	plain, err := e.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, e.AEAD.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("jsondb: generate nonce: %w", err)
+	}
+
+	return e.AEAD.Seal(nonce, nonce, plain, nil), nil
+}
+
+func (e EncryptedCodec) Unmarshal(data []byte, v any) error {
+	nonceSize := e.AEAD.NonceSize()
+	if len(data) < nonceSize {
+		return fmt.Errorf("jsondb: encrypted payload shorter than nonce")
+	}
+
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+
+	plain, err := e.AEAD.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return fmt.Errorf("jsondb: decrypt payload: %w", err)
+	}
+
+	return e.Codec.Unmarshal(plain, v)
+}
+
+func (e EncryptedCodec) FileExtension() string {
+	return e.Codec.FileExtension() + ".enc"
+}
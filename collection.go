@@ -0,0 +1,233 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// collectionKeyPrefix namespaces a Collection's nested storage within
+// DB.data so it can't collide with a caller's own top-level keys.
+const collectionKeyPrefix = "_col:"
+
+// collectionMode selects how a Collection's records are persisted.
+type collectionMode int
+
+const (
+	// collectionNested stores the collection as a single JSON object under
+	// a reserved key in the parent DB's own file.
+	collectionNested collectionMode = iota
+	// collectionDirectory stores the collection as its own file inside a
+	// directory, independent of the parent DB's file.
+	collectionDirectory
+)
+
+// CollectionOption configures a Collection returned by DB.Collection.
+type CollectionOption func(*Collection)
+
+// WithCollectionDir makes the collection directory-backed: its records are
+// persisted to their own file under dir instead of nested inside the
+// parent DB's file.
+func WithCollectionDir(dir string) CollectionOption {
+	return func(c *Collection) {
+		c.mode = collectionDirectory
+		c.dir = dir
+	}
+}
+
+// Collection is a namespaced group of records layered on top of a DB. Each
+// collection has its own lock, so iterating one collection does not block
+// readers of another.
+type Collection struct {
+	db   *DB
+	name string
+	mode collectionMode
+	dir  string
+
+	mu   sync.RWMutex
+	data map[string]json.RawMessage
+}
+
+// Collection returns the named collection, creating and loading it on first
+// use. Repeated calls with the same name return the same *Collection.
+func (db *DB) Collection(name string, opts ...CollectionOption) (*Collection, error) {
+	db.colsMu.Lock()
+	defer db.colsMu.Unlock()
+
+	if db.collections == nil {
+		db.collections = make(map[string]*Collection)
+	}
+
+	if c, ok := db.collections[name]; ok {
+		return c, nil
+	}
+
+	c := &Collection{
+		db:   db,
+		name: name,
+		data: make(map[string]json.RawMessage),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	db.collections[name] = c
+
+	return c, nil
+}
+
+func (c *Collection) storageKey() string {
+	return collectionKeyPrefix + c.name
+}
+
+func (c *Collection) filePath() string {
+	return filepath.Join(c.dir, c.name+c.db.cfg.fileCodec.FileExtension())
+}
+
+func (c *Collection) load() error {
+	switch c.mode {
+	case collectionDirectory:
+		content, err := os.ReadFile(c.filePath())
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return errors.Join(ErrSync, err)
+		}
+
+		if strings.TrimSpace(string(content)) == "" {
+			return nil
+		}
+
+		if err := c.db.cfg.fileCodec.Unmarshal(content, &c.data); err != nil {
+			return errors.Join(ErrJSON, err)
+		}
+
+		return nil
+	default:
+		c.db.mu.RLock()
+		raw, ok := c.db.data[c.storageKey()]
+		c.db.mu.RUnlock()
+
+		if !ok {
+			return nil
+		}
+
+		if err := c.db.cfg.codec.Unmarshal(raw, &c.data); err != nil {
+			return errors.Join(ErrJSON, err)
+		}
+
+		return nil
+	}
+}
+
+// persist writes the collection's current records to durable storage. Unlike
+// DB.Set/Delete, this always writes through: a Collection has no Save/Write
+// analogue to flush a buffered mutation later, so gating on OptSync would
+// silently drop writes with no way to recover them. The caller must hold
+// c.mu.
+func (c *Collection) persist() error {
+	switch c.mode {
+	case collectionDirectory:
+		raw, err := c.db.cfg.fileCodec.Marshal(c.data)
+		if err != nil {
+			return errors.Join(ErrJSON, err)
+		}
+
+		if err := os.MkdirAll(c.dir, 0o755); err != nil {
+			return errors.Join(ErrSync, fmt.Errorf("create collection dir: %w", err))
+		}
+
+		return writeFileAtomic(c.filePath(), raw, c.db.cfg.fsyncFile, c.db.cfg.fsyncDir)
+	default:
+		raw, err := c.db.cfg.codec.Marshal(c.data)
+		if err != nil {
+			return errors.Join(ErrJSON, err)
+		}
+
+		c.db.mu.Lock()
+		defer c.db.mu.Unlock()
+
+		c.db.data[c.storageKey()] = raw
+
+		return c.db.sync()
+	}
+}
+
+// Set stores val under id, replacing any existing record.
+func (c *Collection) Set(id string, val any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	raw, err := c.db.cfg.codec.Marshal(val)
+	if err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	c.data[id] = raw
+
+	return c.persist()
+}
+
+// Get unmarshals the record stored under id into val.
+func (c *Collection) Get(id string, val any) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	raw, ok := c.data[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err := c.db.cfg.codec.Unmarshal(raw, val); err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	return nil
+}
+
+// Delete removes the record stored under id.
+func (c *Collection) Delete(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.data, id)
+
+	return c.persist()
+}
+
+// List returns the ids of every record in the collection.
+func (c *Collection) List() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := make([]string, 0, len(c.data))
+	for id := range c.data {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// Iter calls fn for each record in the collection until fn returns an
+// error.
+func (c *Collection) Iter(fn func(id string, value json.RawMessage) error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for id, v := range c.data {
+		if err := fn(id, v); err != nil {
+			break
+		}
+	}
+}
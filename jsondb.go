@@ -7,11 +7,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
 	"sync"
-
-	"github.com/bytedance/sonic"
 )
 
 var (
@@ -20,20 +17,100 @@ var (
 	ErrSync     = errors.New("sync error")
 )
 
-type Option int64
+// dbConfig holds the resolved settings produced by a DB's Options.
+type dbConfig struct {
+	sync      bool
+	fsyncFile bool
+	fsyncDir  bool
+	codec     Codec
+	fileCodec Codec
+
+	walEnabled   bool
+	walThreshold int64
+}
+
+// Option configures a DB at Open time.
+type Option func(*dbConfig)
+
+// OptSync persists every mutation to disk immediately.
+func OptSync() Option {
+	return func(c *dbConfig) { c.sync = true }
+}
+
+// FsyncLevel controls how durably DB.sync flushes a write to disk.
+type FsyncLevel int
 
 const (
-	OptSync Option = iota
+	// FsyncOff performs no fsync at all (the default): fastest, but a crash
+	// can lose the write or leave it unrenamed.
+	FsyncOff FsyncLevel = iota
+	// FsyncFile fsyncs the temp file before it is renamed over the
+	// destination, so the write itself can't be torn or lost.
+	FsyncFile
+	// FsyncFileAndDir additionally fsyncs the containing directory after
+	// the rename, so the rename itself is durable too.
+	FsyncFileAndDir
 )
 
+// OptFsync controls the durability level DB.sync uses when writing the file:
+// none, file-only, or file-and-directory.
+func OptFsync(level FsyncLevel) Option {
+	return func(c *dbConfig) {
+		c.fsyncFile = level >= FsyncFile
+		c.fsyncDir = level >= FsyncFileAndDir
+	}
+}
+
+// OptCodec selects the Codec used to marshal/unmarshal individual records
+// (Set/Get/SetPath/...). It defaults to SonicCodec, and also seeds
+// OptFileCodec if that option isn't given.
+func OptCodec(codec Codec) Option {
+	return func(c *dbConfig) { c.codec = codec }
+}
+
+// OptFileCodec selects the Codec used to encode/decode the on-disk file (or
+// a directory-backed Collection's file) as a whole, independent of how
+// individual records are encoded. It defaults to whatever OptCodec is set
+// to. EncryptedCodec is meant to be used here: wrapping the whole file
+// keeps it one coherent ciphertext, rather than AEAD-sealing each record
+// separately (which breaks codecs like JSONCodec/SonicCodec, since
+// ciphertext isn't valid JSON and can't be embedded in the all-records
+// envelope they write).
+func OptFileCodec(codec Codec) Option {
+	return func(c *dbConfig) { c.fileCodec = codec }
+}
+
 type DB struct {
-	opts []Option
+	cfg  dbConfig
 	path string
 	data map[string]json.RawMessage
 	mu   sync.RWMutex
+
+	colsMu      sync.Mutex
+	collections map[string]*Collection
+
+	subsMu    sync.Mutex
+	subs      map[int64]*subscriber
+	nextSubID int64
+
+	walSize    int64
+	compacting bool
 }
 
 func Open(path string, opts ...Option) (*DB, error) {
+	cfg := dbConfig{codec: SonicCodec{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.fileCodec == nil {
+		cfg.fileCodec = cfg.codec
+	}
+
+	if cfg.walEnabled && !jsonShapedCodec(cfg.codec) {
+		return nil, fmt.Errorf("jsondb: OptWAL requires a JSON-shaped OptCodec (JSONCodec or SonicCodec), got %T", cfg.codec)
+	}
+
 	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o755)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open/create db file: %w", err)
@@ -52,25 +129,38 @@ func Open(path string, opts ...Option) (*DB, error) {
 	}
 
 	if strings.TrimSpace(string(content)) == "" {
-		content = []byte("{}")
-
-		_, err := file.Write(content)
+		content, err = cfg.fileCodec.Marshal(map[string]json.RawMessage{})
 		if err != nil {
+			return nil, fmt.Errorf("failed to encode new db: %w", err)
+		}
+
+		if _, err := file.Write(content); err != nil {
 			return nil, fmt.Errorf("failed write new db: %w", err)
 		}
 	}
 
 	data := make(map[string]json.RawMessage)
-	if err := sonic.Unmarshal(content, &data); err != nil {
-		return nil, fmt.Errorf("db content is not valid JSON")
+	if err := cfg.fileCodec.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("db content is not valid: %w", err)
 	}
 
-	return &DB{
-		opts: opts,
+	db := &DB{
+		cfg:  cfg,
 		path: absPath,
 		data: data,
 		mu:   sync.RWMutex{},
-	}, nil
+	}
+
+	if cfg.walEnabled {
+		walSize, err := replayWAL(db.walPath(), data)
+		if err != nil {
+			return nil, err
+		}
+
+		db.walSize = walSize
+	}
+
+	return db, nil
 }
 
 func (db *DB) Save() error {
@@ -80,21 +170,84 @@ func (db *DB) Save() error {
 	return db.sync()
 }
 
+// sync rewrites the whole DB file from the current in-memory state. In WAL
+// mode, the rewritten file already reflects every record the WAL was
+// buffering, so the WAL is truncated as part of the same call; callers
+// (Save, Write, Compact, and the non-WAL path of persistMutation) don't need
+// their own WAL bookkeeping.
 func (db *DB) sync() error {
-	content, err := json.Marshal(db.data)
+	content, err := db.cfg.fileCodec.Marshal(db.data)
 	if err != nil {
 		return errors.Join(ErrJSON, err)
 	}
 
-	if err := os.WriteFile(db.path, content, 0o755); err != nil {
-		return errors.Join(ErrSync, err)
+	if err := writeFileAtomic(db.path, content, db.cfg.fsyncFile, db.cfg.fsyncDir); err != nil {
+		return err
+	}
+
+	if db.cfg.walEnabled {
+		return db.truncateWAL()
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes content to a sibling ".tmp" file and renames it
+// over path, optionally fsyncing the file and/or its containing directory.
+// It is shared by DB.sync and directory-backed Collections.
+func writeFileAtomic(path string, content []byte, fsyncFile, fsyncDir bool) error {
+	tmpPath := path + ".tmp"
+
+	tmpFile, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o755)
+	if err != nil {
+		return errors.Join(ErrSync, fmt.Errorf("open temp file: %w", err))
+	}
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+
+		return errors.Join(ErrSync, fmt.Errorf("write temp file: %w", err))
+	}
+
+	if fsyncFile || fsyncDir {
+		if err := tmpFile.Sync(); err != nil {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+
+			return errors.Join(ErrSync, fmt.Errorf("fsync temp file: %w", err))
+		}
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Join(ErrSync, fmt.Errorf("close temp file: %w", err))
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+
+		return errors.Join(ErrSync, fmt.Errorf("rename temp file: %w", err))
+	}
+
+	if fsyncDir {
+		dir, err := os.Open(filepath.Dir(path))
+		if err != nil {
+			return errors.Join(ErrSync, fmt.Errorf("open containing directory: %w", err))
+		}
+		defer dir.Close()
+
+		if err := dir.Sync(); err != nil {
+			return errors.Join(ErrSync, fmt.Errorf("fsync containing directory: %w", err))
+		}
 	}
 
 	return nil
 }
 
 func (db *DB) syncIfNeeded() error {
-	if slices.Contains(db.opts, OptSync) {
+	if db.cfg.sync {
 		if err := db.sync(); err != nil {
 			return err
 		}
@@ -103,18 +256,29 @@ func (db *DB) syncIfNeeded() error {
 	return nil
 }
 
+// persistMutation durably records a single mutation. In WAL mode this
+// appends a WAL record instead of rewriting the whole file; otherwise it
+// falls back to the OptSync policy. The caller must hold db.mu for writing.
+func (db *DB) persistMutation(op Op, key string, val json.RawMessage) error {
+	if db.cfg.walEnabled {
+		return db.appendWAL(op, key, val)
+	}
+
+	return db.syncIfNeeded()
+}
+
 func (db *DB) Set(key string, val any) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	raw, err := sonic.Marshal(val)
+	raw, err := setLocked(db.cfg.codec, db.data, key, val)
 	if err != nil {
-		return errors.Join(ErrJSON, err)
+		return err
 	}
 
-	db.data[key] = raw
+	db.publish(Event{Op: OpSet, Key: key, Value: raw})
 
-	return db.syncIfNeeded()
+	return db.persistMutation(OpSet, key, raw)
 }
 
 func (db *DB) SetRaw(key string, val json.RawMessage) error {
@@ -123,35 +287,23 @@ func (db *DB) SetRaw(key string, val json.RawMessage) error {
 
 	db.data[key] = val
 
-	return db.syncIfNeeded()
+	db.publish(Event{Op: OpSet, Key: key, Value: val})
+
+	return db.persistMutation(OpSet, key, val)
 }
 
 func (db *DB) Get(key string, val interface{}) error {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	raw, ok := db.data[key]
-	if !ok {
-		return ErrNotFound
-	}
-
-	if err := sonic.Unmarshal(raw, val); err != nil {
-		return errors.Join(ErrJSON, err)
-	}
-
-	return nil
+	return getLocked(db.cfg.codec, db.data, key, val)
 }
 
 func (db *DB) GetRaw(key string) (json.RawMessage, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	raw, ok := db.data[key]
-	if !ok {
-		return nil, ErrNotFound
-	}
-
-	return raw, nil
+	return getRawLocked(db.data, key)
 }
 
 func (db *DB) Delete(key string) error {
@@ -160,14 +312,72 @@ func (db *DB) Delete(key string) error {
 
 	delete(db.data, key)
 
-	return db.syncIfNeeded()
+	db.publish(Event{Op: OpDelete, Key: key})
+
+	return db.persistMutation(OpDelete, key, nil)
 }
 
 func (db *DB) Iter(fn func(key string, value json.RawMessage) error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	for k, v := range db.data {
+	iterLocked(db.data, fn)
+}
+
+// setLocked marshals val with codec and stores it under key in data,
+// returning the encoded bytes. The caller must hold db.mu for writing.
+func setLocked(codec Codec, data map[string]json.RawMessage, key string, val any) (json.RawMessage, error) {
+	raw, err := codec.Marshal(val)
+	if err != nil {
+		return nil, errors.Join(ErrJSON, err)
+	}
+
+	data[key] = raw
+
+	return raw, nil
+}
+
+// getLocked unmarshals the value stored under key into val using codec. The
+// caller must hold db.mu for reading (or writing).
+func getLocked(codec Codec, data map[string]json.RawMessage, key string, val any) error {
+	raw, ok := data[key]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err := codec.Unmarshal(raw, val); err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	return nil
+}
+
+// getRawLocked returns the raw value stored under key. The caller must hold
+// db.mu for reading (or writing). Keys namespaced for Collection storage
+// (see collectionKeyPrefix) are internal and never visible here.
+func getRawLocked(data map[string]json.RawMessage, key string) (json.RawMessage, error) {
+	if strings.HasPrefix(key, collectionKeyPrefix) {
+		return nil, ErrNotFound
+	}
+
+	raw, ok := data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return raw, nil
+}
+
+// iterLocked walks data, invoking fn for each entry until fn returns an
+// error. The caller must hold db.mu for reading (or writing). Keys
+// namespaced for Collection storage (see collectionKeyPrefix) are internal
+// and skipped.
+func iterLocked(data map[string]json.RawMessage, fn func(key string, value json.RawMessage) error) {
+	for k, v := range data {
+		if strings.HasPrefix(k, collectionKeyPrefix) {
+			continue
+		}
+
 		if err := fn(k, v); err != nil {
 			break
 		}
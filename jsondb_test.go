@@ -0,0 +1,89 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSaveIsAtomicAndLeavesNoTempFile(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	db, err := Open(path, OptFsync(FsyncFileAndDir))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := db.Set("k", "v1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if err := db.Set("k", "v2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := db.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temp file left behind after Save: err = %v", err)
+	}
+
+	db2, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	var v string
+	if err := db2.Get("k", &v); err != nil || v != "v2" {
+		t.Fatalf("k = %q, %v; want v2, nil", v, err)
+	}
+}
+
+func TestWriteTransactionPublishesEventsOnlyAfterCommit(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	events, unsubscribe := db.Watch("")
+	defer unsubscribe()
+
+	if err := db.Write(func(tx *Tx) error {
+		if err := tx.Set("k", "v1"); err != nil {
+			return err
+		}
+
+		select {
+		case ev := <-events:
+			t.Errorf("event published before commit: %+v", ev)
+		default:
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Key != "k" || ev.Op != OpSet {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	default:
+		t.Fatal("expected a Watch event from a committed Write transaction")
+	}
+
+	var v string
+	if err := db.Get("k", &v); err != nil || v != "v1" {
+		t.Fatalf("k = %q, %v; want v1, nil", v, err)
+	}
+}
@@ -0,0 +1,414 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is one step of a dotted/bracketed JSON path: either an object
+// key or an array index.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePathTail parses a single dot-separated path part (e.g. "webhook[0]"
+// or "42") into one or more segments.
+func parsePathTail(part string) ([]pathSegment, error) {
+	if part == "" {
+		return nil, fmt.Errorf("jsondb: empty path segment")
+	}
+
+	var segs []pathSegment
+
+	rest := part
+	if rest[0] != '[' {
+		name := rest
+		if i := strings.IndexByte(rest, '['); i >= 0 {
+			name = rest[:i]
+			rest = rest[i:]
+		} else {
+			rest = ""
+		}
+
+		if n, err := strconv.Atoi(name); err == nil {
+			segs = append(segs, pathSegment{isIndex: true, index: n})
+		} else {
+			segs = append(segs, pathSegment{key: name})
+		}
+	}
+
+	for len(rest) > 0 {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("jsondb: malformed path segment %q", part)
+		}
+
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("jsondb: unterminated '[' in path segment %q", part)
+		}
+
+		n, err := strconv.Atoi(rest[1:end])
+		if err != nil {
+			return nil, fmt.Errorf("jsondb: non-numeric index %q in path segment %q", rest[1:end], part)
+		}
+
+		segs = append(segs, pathSegment{isIndex: true, index: n})
+		rest = rest[end+1:]
+	}
+
+	return segs, nil
+}
+
+// splitPath splits a dotted/bracketed path into the top-level DB key (always
+// a plain string, since DB.data is keyed by string) and the remaining
+// segments to traverse inside that key's value.
+func splitPath(path string) (key string, segs []pathSegment, err error) {
+	if path == "" {
+		return "", nil, fmt.Errorf("jsondb: empty path")
+	}
+
+	parts := strings.Split(path, ".")
+
+	first := parts[0]
+	if first == "" {
+		return "", nil, fmt.Errorf("jsondb: empty path segment in %q", path)
+	}
+
+	if i := strings.IndexByte(first, '['); i >= 0 {
+		key = first[:i]
+
+		brackets, err := parsePathTail(first[i:])
+		if err != nil {
+			return "", nil, err
+		}
+
+		segs = append(segs, brackets...)
+	} else {
+		key = first
+	}
+
+	if key == "" {
+		return "", nil, fmt.Errorf("jsondb: empty top-level key in path %q", path)
+	}
+
+	for _, part := range parts[1:] {
+		tail, err := parsePathTail(part)
+		if err != nil {
+			return "", nil, err
+		}
+
+		segs = append(segs, tail...)
+	}
+
+	return key, segs, nil
+}
+
+func getPathValue(root any, segs []pathSegment) (any, bool) {
+	cur := root
+
+	for _, seg := range segs {
+		if seg.isIndex {
+			arr, ok := cur.([]any)
+			if !ok || seg.index < 0 || seg.index >= len(arr) {
+				return nil, false
+			}
+
+			cur = arr[seg.index]
+		} else {
+			m, ok := cur.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+
+			v, ok := m[seg.key]
+			if !ok {
+				return nil, false
+			}
+
+			cur = v
+		}
+	}
+
+	return cur, true
+}
+
+// setPathValue returns a new root with val set at segs, creating
+// intermediate objects and arrays as needed.
+func setPathValue(root any, segs []pathSegment, val any) (any, error) {
+	if len(segs) == 0 {
+		return val, nil
+	}
+
+	seg := segs[0]
+
+	if seg.isIndex {
+		if seg.index < 0 {
+			return nil, fmt.Errorf("jsondb: negative array index %d", seg.index)
+		}
+
+		var arr []any
+
+		switch v := root.(type) {
+		case nil:
+		case []any:
+			arr = v
+		default:
+			return nil, fmt.Errorf("jsondb: cannot set index %d on non-array value", seg.index)
+		}
+
+		for len(arr) <= seg.index {
+			arr = append(arr, nil)
+		}
+
+		child, err := setPathValue(arr[seg.index], segs[1:], val)
+		if err != nil {
+			return nil, err
+		}
+
+		arr[seg.index] = child
+
+		return arr, nil
+	}
+
+	var m map[string]any
+
+	switch v := root.(type) {
+	case nil:
+		m = map[string]any{}
+	case map[string]any:
+		m = v
+	default:
+		return nil, fmt.Errorf("jsondb: cannot set key %q on non-object value", seg.key)
+	}
+
+	child, err := setPathValue(m[seg.key], segs[1:], val)
+	if err != nil {
+		return nil, err
+	}
+
+	m[seg.key] = child
+
+	return m, nil
+}
+
+// deletePathValue returns a new root with the value at segs removed,
+// leaving sibling keys/elements intact.
+func deletePathValue(root any, segs []pathSegment) (any, error) {
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("jsondb: empty path")
+	}
+
+	seg := segs[0]
+
+	if len(segs) == 1 {
+		if seg.isIndex {
+			arr, ok := root.([]any)
+			if !ok || seg.index < 0 || seg.index >= len(arr) {
+				return root, ErrNotFound
+			}
+
+			return append(arr[:seg.index:seg.index], arr[seg.index+1:]...), nil
+		}
+
+		m, ok := root.(map[string]any)
+		if !ok {
+			return root, ErrNotFound
+		}
+
+		if _, ok := m[seg.key]; !ok {
+			return root, ErrNotFound
+		}
+
+		delete(m, seg.key)
+
+		return m, nil
+	}
+
+	if seg.isIndex {
+		arr, ok := root.([]any)
+		if !ok || seg.index < 0 || seg.index >= len(arr) {
+			return root, ErrNotFound
+		}
+
+		child, err := deletePathValue(arr[seg.index], segs[1:])
+		if err != nil {
+			return root, err
+		}
+
+		arr[seg.index] = child
+
+		return arr, nil
+	}
+
+	m, ok := root.(map[string]any)
+	if !ok {
+		return root, ErrNotFound
+	}
+
+	v, ok := m[seg.key]
+	if !ok {
+		return root, ErrNotFound
+	}
+
+	child, err := deletePathValue(v, segs[1:])
+	if err != nil {
+		return root, err
+	}
+
+	m[seg.key] = child
+
+	return m, nil
+}
+
+// SetPath sets val at a dotted/bracketed JSON path (e.g. "users.42.name" or
+// "notify.webhook[0].url"), creating intermediate objects and arrays as
+// needed.
+func (db *DB) SetPath(path string, val any) error {
+	key, segs, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	if len(segs) > 0 && !jsonShapedCodec(db.cfg.codec) {
+		return fmt.Errorf("jsondb: path traversal into %q requires a JSON-shaped OptCodec (JSONCodec or SonicCodec), got %T", path, db.cfg.codec)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var root any
+	if raw, ok := db.data[key]; ok {
+		if err := db.cfg.codec.Unmarshal(raw, &root); err != nil {
+			return errors.Join(ErrJSON, err)
+		}
+	}
+
+	valBytes, err := db.cfg.codec.Marshal(val)
+	if err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	var valAny any
+	if err := db.cfg.codec.Unmarshal(valBytes, &valAny); err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	newRoot, err := setPathValue(root, segs, valAny)
+	if err != nil {
+		return err
+	}
+
+	raw, err := db.cfg.codec.Marshal(newRoot)
+	if err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	db.data[key] = raw
+
+	db.publish(Event{Op: OpSet, Key: key, Value: raw})
+
+	return db.persistMutation(OpSet, key, raw)
+}
+
+// GetPath unmarshals the value at a dotted/bracketed JSON path into val.
+func (db *DB) GetPath(path string, val any) error {
+	key, segs, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	if len(segs) > 0 && !jsonShapedCodec(db.cfg.codec) {
+		return fmt.Errorf("jsondb: path traversal into %q requires a JSON-shaped OptCodec (JSONCodec or SonicCodec), got %T", path, db.cfg.codec)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	raw, ok := db.data[key]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if len(segs) == 0 {
+		if err := db.cfg.codec.Unmarshal(raw, val); err != nil {
+			return errors.Join(ErrJSON, err)
+		}
+
+		return nil
+	}
+
+	var root any
+	if err := db.cfg.codec.Unmarshal(raw, &root); err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	v, ok := getPathValue(root, segs)
+	if !ok {
+		return ErrNotFound
+	}
+
+	b, err := db.cfg.codec.Marshal(v)
+	if err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	if err := db.cfg.codec.Unmarshal(b, val); err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	return nil
+}
+
+// DeletePath removes the value at a dotted/bracketed JSON path, pruning only
+// the leaf and leaving siblings intact.
+func (db *DB) DeletePath(path string) error {
+	key, segs, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	if len(segs) > 0 && !jsonShapedCodec(db.cfg.codec) {
+		return fmt.Errorf("jsondb: path traversal into %q requires a JSON-shaped OptCodec (JSONCodec or SonicCodec), got %T", path, db.cfg.codec)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	raw, ok := db.data[key]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if len(segs) == 0 {
+		delete(db.data, key)
+
+		db.publish(Event{Op: OpDelete, Key: key})
+
+		return db.persistMutation(OpDelete, key, nil)
+	}
+
+	var root any
+	if err := db.cfg.codec.Unmarshal(raw, &root); err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	newRoot, err := deletePathValue(root, segs)
+	if err != nil {
+		return err
+	}
+
+	newRaw, err := db.cfg.codec.Marshal(newRoot)
+	if err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	db.data[key] = newRaw
+
+	db.publish(Event{Op: OpSet, Key: key, Value: newRaw})
+
+	return db.persistMutation(OpSet, key, newRaw)
+}
@@ -0,0 +1,78 @@
+package jsondb
+
+import (
+	"testing"
+)
+
+func TestPathRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := db.SetPath("users[0].name", "alice"); err != nil {
+		t.Fatalf("SetPath: %v", err)
+	}
+
+	if err := db.SetPath("users[0].tags[1]", "admin"); err != nil {
+		t.Fatalf("SetPath: %v", err)
+	}
+
+	var name string
+	if err := db.GetPath("users[0].name", &name); err != nil || name != "alice" {
+		t.Fatalf("GetPath name = %q, %v; want alice, nil", name, err)
+	}
+
+	var tag string
+	if err := db.GetPath("users[0].tags[1]", &tag); err != nil || tag != "admin" {
+		t.Fatalf("GetPath tag = %q, %v; want admin, nil", tag, err)
+	}
+
+	if err := db.DeletePath("users[0].name"); err != nil {
+		t.Fatalf("DeletePath: %v", err)
+	}
+
+	if err := db.GetPath("users[0].name", &name); err != ErrNotFound {
+		t.Fatalf("GetPath after delete: got err %v, want ErrNotFound", err)
+	}
+
+	if err := db.GetPath("users[0].tags[1]", &tag); err != nil || tag != "admin" {
+		t.Fatalf("sibling clobbered by DeletePath: tag = %q, %v", tag, err)
+	}
+}
+
+func TestPathTraversalRejectsNonJSONCodec(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.cbor"
+
+	db, err := Open(path, OptCodec(CBORCodec{}))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := db.SetPath("users.0.name", "alice"); err == nil {
+		t.Fatal("expected SetPath traversal under CBORCodec to be rejected")
+	}
+
+	if err := db.GetPath("users.0.name", new(string)); err == nil {
+		t.Fatal("expected GetPath traversal under CBORCodec to be rejected")
+	}
+
+	if err := db.DeletePath("users.0.name"); err == nil {
+		t.Fatal("expected DeletePath traversal under CBORCodec to be rejected")
+	}
+
+	// Top-level (no traversal) path ops don't type-assert into
+	// map[string]any/[]any, so they remain safe under any codec.
+	if err := db.SetPath("name", "bob"); err != nil {
+		t.Fatalf("top-level SetPath under CBORCodec: %v", err)
+	}
+
+	var name string
+	if err := db.GetPath("name", &name); err != nil || name != "bob" {
+		t.Fatalf("top-level GetPath under CBORCodec = %q, %v; want bob, nil", name, err)
+	}
+}
@@ -0,0 +1,101 @@
+package jsondb
+
+import "encoding/json"
+
+// Snapshot is a read-only view over the DB's data as of the start of a Read
+// call.
+type Snapshot interface {
+	Get(key string, val any) error
+	GetRaw(key string) (json.RawMessage, error)
+	Iter(fn func(key string, value json.RawMessage) error)
+}
+
+// Tx is a read-write view over the DB's data as of the start of a Write
+// call. Mutations made through a Tx are only visible to other callers once
+// the Write closure returns nil and the DB has been persisted. The Watch
+// events they produce are likewise only published once the Tx commits.
+type Tx struct {
+	db     *DB
+	events []Event
+}
+
+func (tx *Tx) Set(key string, val any) error {
+	raw, err := setLocked(tx.db.cfg.codec, tx.db.data, key, val)
+	if err != nil {
+		return err
+	}
+
+	tx.events = append(tx.events, Event{Op: OpSet, Key: key, Value: raw})
+
+	return nil
+}
+
+func (tx *Tx) SetRaw(key string, val json.RawMessage) {
+	tx.db.data[key] = val
+	tx.events = append(tx.events, Event{Op: OpSet, Key: key, Value: val})
+}
+
+func (tx *Tx) Get(key string, val any) error {
+	return getLocked(tx.db.cfg.codec, tx.db.data, key, val)
+}
+
+func (tx *Tx) GetRaw(key string) (json.RawMessage, error) {
+	return getRawLocked(tx.db.data, key)
+}
+
+func (tx *Tx) Delete(key string) {
+	delete(tx.db.data, key)
+	tx.events = append(tx.events, Event{Op: OpDelete, Key: key})
+}
+
+func (tx *Tx) Iter(fn func(key string, value json.RawMessage) error) {
+	iterLocked(tx.db.data, fn)
+}
+
+// Read takes the DB's read lock and invokes fn with a Snapshot of the
+// current data. fn must not retain the Snapshot beyond the call.
+func (db *DB) Read(fn func(view Snapshot) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return fn(&Tx{db: db})
+}
+
+// Write takes the DB's exclusive lock and invokes fn with a *Tx over the
+// current data. If fn returns an error or panics, every mutation made
+// through the Tx is rolled back and the panic, if any, is re-raised. If fn
+// returns nil, the DB is persisted atomically exactly once, regardless of
+// how many mutations fn made.
+func (db *DB) Write(fn func(tx *Tx) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	snapshot := make(map[string]json.RawMessage, len(db.data))
+	for k, v := range db.data {
+		snapshot[k] = v
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			db.data = snapshot
+		}
+	}()
+
+	tx := &Tx{db: db}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := db.sync(); err != nil {
+		return err
+	}
+
+	committed = true
+
+	for _, ev := range tx.events {
+		db.publish(ev)
+	}
+
+	return nil
+}
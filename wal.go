@@ -0,0 +1,181 @@
+package jsondb
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// OptWAL enables write-ahead-log mode: Set/Delete append a small record to
+// a sibling ".wal" file (fsynced) instead of rewriting the whole DB file.
+// Once the WAL exceeds thresholdBytes, a background Compact runs
+// automatically; thresholdBytes <= 0 disables automatic compaction and
+// leaves it to explicit calls to DB.Compact. WAL records are always
+// JSON-lines, so this requires a JSON-shaped per-record Codec
+// (JSONCodec/SonicCodec); Open rejects OptWAL combined with any other
+// OptCodec.
+func OptWAL(thresholdBytes int64) Option {
+	return func(c *dbConfig) {
+		c.walEnabled = true
+		c.walThreshold = thresholdBytes
+	}
+}
+
+// walRecord is a single append-only JSON-lines entry in the WAL file.
+type walRecord struct {
+	Op Op              `json:"op"`
+	K  string          `json:"k"`
+	V  json.RawMessage `json:"v,omitempty"`
+}
+
+func (op Op) MarshalJSON() ([]byte, error) {
+	switch op {
+	case OpSet:
+		return json.Marshal("set")
+	case OpDelete:
+		return json.Marshal("del")
+	default:
+		return nil, fmt.Errorf("jsondb: unknown op %d", op)
+	}
+}
+
+func (op *Op) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch s {
+	case "set":
+		*op = OpSet
+	case "del":
+		*op = OpDelete
+	default:
+		return fmt.Errorf("jsondb: unknown wal op %q", s)
+	}
+
+	return nil
+}
+
+func (db *DB) walPath() string {
+	return db.path + ".wal"
+}
+
+// replayWAL applies every record in the WAL at path to data, returning the
+// WAL file's size in bytes (0 if it doesn't exist yet).
+func replayWAL(path string, data map[string]json.RawMessage) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, errors.Join(ErrSync, fmt.Errorf("open wal: %w", err))
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, errors.Join(ErrSync, fmt.Errorf("stat wal: %w", err))
+	}
+
+	// A plain bufio.Scanner caps lines at its default 64KB token size, which
+	// a single large record would exceed; read with bufio.Reader instead,
+	// which has no such limit.
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, errors.Join(ErrSync, fmt.Errorf("read wal: %w", err))
+		}
+
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			var rec walRecord
+			if unmarshalErr := json.Unmarshal([]byte(trimmed), &rec); unmarshalErr != nil {
+				return 0, errors.Join(ErrJSON, fmt.Errorf("corrupt wal record: %w", unmarshalErr))
+			}
+
+			switch rec.Op {
+			case OpSet:
+				data[rec.K] = rec.V
+			case OpDelete:
+				delete(data, rec.K)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return info.Size(), nil
+}
+
+// appendWAL appends a single record for op to the WAL file and fsyncs it.
+// The caller must hold db.mu for writing. If the WAL has grown past the
+// configured threshold, it kicks off an asynchronous Compact.
+func (db *DB) appendWAL(op Op, key string, val json.RawMessage) error {
+	line, err := json.Marshal(walRecord{Op: op, K: key, V: val})
+	if err != nil {
+		return errors.Join(ErrJSON, err)
+	}
+
+	line = append(line, '\n')
+
+	file, err := os.OpenFile(db.walPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o755)
+	if err != nil {
+		return errors.Join(ErrSync, fmt.Errorf("open wal: %w", err))
+	}
+	defer file.Close()
+
+	n, err := file.Write(line)
+	if err != nil {
+		return errors.Join(ErrSync, fmt.Errorf("append wal: %w", err))
+	}
+
+	if err := file.Sync(); err != nil {
+		return errors.Join(ErrSync, fmt.Errorf("fsync wal: %w", err))
+	}
+
+	db.walSize += int64(n)
+
+	if db.cfg.walThreshold > 0 && db.walSize >= db.cfg.walThreshold && !db.compacting {
+		db.compacting = true
+
+		go func() {
+			_ = db.Compact()
+		}()
+	}
+
+	return nil
+}
+
+// truncateWAL discards WAL records now that sync has rewritten the base file
+// from the current in-memory state, so nothing in the WAL is needed to
+// reconstruct it anymore. The caller must hold db.mu for writing.
+func (db *DB) truncateWAL() error {
+	if err := os.Truncate(db.walPath(), 0); err != nil && !os.IsNotExist(err) {
+		return errors.Join(ErrSync, fmt.Errorf("truncate wal: %w", err))
+	}
+
+	db.walSize = 0
+
+	return nil
+}
+
+// Compact rewrites the base DB file from the current in-memory state and
+// truncates the WAL. It is safe to call at any time in WAL mode; it is a
+// no-op (beyond a normal sync) otherwise.
+func (db *DB) Compact() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.compacting = false
+
+	return db.sync()
+}
@@ -0,0 +1,121 @@
+package jsondb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWALReplay(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	db, err := Open(path, OptWAL(1<<30))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := db.Set("k1", "v1"); err != nil {
+		t.Fatalf("Set k1: %v", err)
+	}
+
+	if err := db.Set("k2", "v2"); err != nil {
+		t.Fatalf("Set k2: %v", err)
+	}
+
+	if err := db.Delete("k1"); err != nil {
+		t.Fatalf("Delete k1: %v", err)
+	}
+
+	db2, err := Open(path, OptWAL(1<<30))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	if _, err := db2.GetRaw("k1"); err != ErrNotFound {
+		t.Fatalf("k1: got err %v, want ErrNotFound", err)
+	}
+
+	var v string
+	if err := db2.Get("k2", &v); err != nil || v != "v2" {
+		t.Fatalf("k2 = %q, %v; want v2, nil", v, err)
+	}
+}
+
+// TestWALReplayLargeRecord covers the case where a single WAL line exceeds
+// bufio.Scanner's default 64KB token size.
+func TestWALReplayLargeRecord(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	db, err := Open(path, OptWAL(1<<30))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	big := strings.Repeat("x", 100*1024)
+	if err := db.Set("big", big); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	db2, err := Open(path, OptWAL(1<<30))
+	if err != nil {
+		t.Fatalf("reopen after >64KB wal record: %v", err)
+	}
+
+	var v string
+	if err := db2.Get("big", &v); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if v != big {
+		t.Fatalf("got %d bytes back, want %d", len(v), len(big))
+	}
+}
+
+// TestWALTruncatedOnSync covers sync() truncating the WAL on every full
+// rewrite, not just Compact: without it, a stale WAL record can replay over
+// fresher data written via a path that ends in sync().
+func TestWALTruncatedOnSync(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	db, err := Open(path, OptWAL(1<<30))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := db.Set("k", "v1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := db.Delete("k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if err := db.Write(func(tx *Tx) error { return tx.Set("k", "v2") }); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	db2, err := Open(path, OptWAL(1<<30))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	var v string
+	if err := db2.Get("k", &v); err != nil {
+		t.Fatalf("k not found after reopen: %v", err)
+	}
+
+	if v != "v2" {
+		t.Fatalf("k = %q, want v2", v)
+	}
+}
+
+func TestOptWALRejectsNonJSONCodec(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/db.json"
+
+	if _, err := Open(path, OptWAL(1<<30), OptCodec(CBORCodec{})); err == nil {
+		t.Fatal("expected Open to reject OptWAL combined with a non-JSON codec")
+	}
+}
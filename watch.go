@@ -0,0 +1,101 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// watchBufferSize is the number of undelivered events buffered per
+// subscriber before the overflow policy kicks in.
+const watchBufferSize = 64
+
+// Op identifies the kind of mutation that produced an Event.
+type Op int
+
+const (
+	OpSet Op = iota
+	OpDelete
+)
+
+// Event describes a single Set/SetRaw/Delete mutation on a key matching a
+// Watch subscription's prefix.
+type Event struct {
+	Op    Op
+	Key   string
+	Value json.RawMessage
+}
+
+type subscriber struct {
+	prefix string
+	ch     chan Event
+}
+
+// Watch subscribes to mutations on keys matching prefix, returning a
+// channel of Events and an unsubscribe function. Delivery is buffered and
+// non-blocking: if a subscriber falls behind, the oldest undelivered event
+// is dropped to make room for the newest. Calling the returned unsubscribe
+// function closes the channel; it is safe to call more than once.
+func (db *DB) Watch(prefix string) (<-chan Event, func()) {
+	db.subsMu.Lock()
+	defer db.subsMu.Unlock()
+
+	if db.subs == nil {
+		db.subs = make(map[int64]*subscriber)
+	}
+
+	id := db.nextSubID
+	db.nextSubID++
+
+	sub := &subscriber{prefix: prefix, ch: make(chan Event, watchBufferSize)}
+	db.subs[id] = sub
+
+	unsubscribe := func() {
+		db.subsMu.Lock()
+		defer db.subsMu.Unlock()
+
+		if _, ok := db.subs[id]; !ok {
+			return
+		}
+
+		delete(db.subs, id)
+		close(sub.ch)
+	}
+
+	return sub.ch, unsubscribe
+}
+
+// publish delivers ev to every subscriber whose prefix matches. The caller
+// must hold db.mu for writing, so this runs before the write lock is
+// released. Keys namespaced for Collection storage (see collectionKeyPrefix)
+// are internal and never published.
+func (db *DB) publish(ev Event) {
+	if strings.HasPrefix(ev.Key, collectionKeyPrefix) {
+		return
+	}
+
+	db.subsMu.Lock()
+	defer db.subsMu.Unlock()
+
+	for _, sub := range db.subs {
+		if !strings.HasPrefix(ev.Key, sub.prefix) {
+			continue
+		}
+
+		select {
+		case sub.ch <- ev:
+			continue
+		default:
+		}
+
+		// Overflow: drop the oldest buffered event to make room for ev.
+		select {
+		case <-sub.ch:
+		default:
+		}
+
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+}